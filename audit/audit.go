@@ -0,0 +1,255 @@
+// Package audit records every tool call the chat loop makes as
+// newline-delimited JSON, with redaction hooks so secrets and large file
+// bodies don't end up sitting in the log. `thoth replay <log>` re-executes
+// a recorded log against a fresh project root to reproduce agent behavior
+// deterministically.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Entry is one recorded tool call.
+type Entry struct {
+	Timestamp  time.Time      `json:"timestamp"`
+	Tool       string         `json:"tool"`
+	Args       map[string]any `json:"args"`
+	Result     map[string]any `json:"result,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+	SessionID  string         `json:"session_id"`
+	TurnID     int            `json:"turn_id"`
+}
+
+// Sink is where recorded entries go: a file, stderr, or an HTTP endpoint.
+type Sink interface {
+	Write(Entry) error
+}
+
+// fileSink appends newline-delimited JSON entries to a file.
+type fileSink struct {
+	w io.Writer
+}
+
+// NewFileSink wraps an already-open, append-mode writer (typically an
+// *os.File) as a Sink.
+func NewFileSink(w io.Writer) Sink {
+	return &fileSink{w: w}
+}
+
+func (s *fileSink) Write(e Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = s.w.Write(b)
+	return err
+}
+
+// httpSink POSTs each entry as a JSON body to url.
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSink posts each entry to url as it's recorded.
+func NewHTTPSink(url string) Sink {
+	return &httpSink{url: url, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (s *httpSink) Write(e Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", strings.NewReader(string(b)))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("audit sink %s: status %d", s.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// secretKeyPatterns flag field names that look like credential variables
+// (e.g. an env var GITHUB_TOKEN), regardless of which tool produced them.
+var secretKeyPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)_TOKEN$`),
+	regexp.MustCompile(`(?i)_KEY$`),
+}
+
+// secretValuePatterns flag string values that look like credentials by
+// their shape, independent of the field name they appear under.
+var secretValuePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`AKIA[0-9A-Z]{16}`),
+}
+
+// secretAssignmentPattern matches a `NAME=VALUE` assignment embedded in
+// free-form text (a shell command or its captured output, e.g. `export
+// GITHUB_TOKEN=ghp_xxx`) where NAME looks like a credential variable, so
+// the value can be redacted without requiring it to be a standalone
+// top-level field.
+var secretAssignmentPattern = regexp.MustCompile(`(?i)(\b[\w.]*(?:_TOKEN|_KEY)\b)(\s*=\s*)(\S+)`)
+
+// redactSecretsInText scans free-form text for embedded secrets by shape
+// — a KEY=value assignment or an AKIA-style access key — and redacts just
+// the matched portion, leaving the rest of the text (the command, the
+// surrounding log line) intact.
+func redactSecretsInText(s string) string {
+	s = secretAssignmentPattern.ReplaceAllString(s, "${1}${2}[REDACTED]")
+	for _, re := range secretValuePatterns {
+		s = re.ReplaceAllString(s, "[REDACTED]")
+	}
+	return s
+}
+
+// Logger wraps every Tool.Execute call the chat loop makes, recording an
+// Entry to Sink for each one.
+type Logger struct {
+	Sink      Sink
+	SessionID string
+
+	// InlineBodyLimit caps how many bytes of a field like
+	// read_many_files' "content" are logged inline; past that, the field
+	// is replaced with {sha256, size}. Zero means no limit.
+	InlineBodyLimit int
+
+	turnID int
+}
+
+// NewLogger returns a Logger writing to sink, identified by sessionID.
+func NewLogger(sink Sink, sessionID string) *Logger {
+	return &Logger{Sink: sink, SessionID: sessionID, InlineBodyLimit: 4096}
+}
+
+// NextTurn advances and returns the turn id, so every tool call made while
+// handling one user message shares a TurnID.
+func (l *Logger) NextTurn() int {
+	l.turnID++
+	return l.turnID
+}
+
+// Record wraps a single tool call: it calls exec, measures its duration,
+// and writes an Entry (redacted per-tool) to the sink.
+func (l *Logger) Record(tool string, turnID int, args map[string]any, exec func() (map[string]any, error)) (map[string]any, error) {
+	start := time.Now()
+	result, err := exec()
+
+	entry := Entry{
+		Timestamp:  start,
+		Tool:       tool,
+		Args:       redact(tool, args, l.InlineBodyLimit),
+		DurationMs: time.Since(start).Milliseconds(),
+		SessionID:  l.SessionID,
+		TurnID:     turnID,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Result = redact(tool, result, l.InlineBodyLimit)
+	}
+
+	if l.Sink != nil {
+		if sinkErr := l.Sink.Write(entry); sinkErr != nil {
+			// Logging failures shouldn't take down the chat loop; the
+			// caller already has the real result/error to act on.
+			_ = sinkErr
+		}
+	}
+
+	return result, err
+}
+
+// redact applies the generic secret-pattern check to every key/value pair,
+// plus the read_many_files-specific body hashing, without mutating data.
+func redact(tool string, data map[string]any, inlineLimit int) map[string]any {
+	if data == nil {
+		return nil
+	}
+	out := make(map[string]any, len(data))
+	for k, v := range data {
+		out[k] = redactValue(tool, k, v, inlineLimit)
+	}
+	return out
+}
+
+// largeBodyFields are the args/result fields big enough, across any tool,
+// to need hashing instead of inline logging once they pass inlineLimit
+// (read_many_files' file content, run_shell_command's captured output).
+var largeBodyFields = map[string]bool{
+	"content": true,
+	"Stdout":  true,
+	"Stderr":  true,
+}
+
+// scannedTextFields are fields whose value is free-form text that can
+// carry an embedded secret (an exported env var, a credential echoed to
+// the terminal) rather than being a secret outright, so they're scanned
+// by shape instead of redacted wholesale.
+var scannedTextFields = map[string]bool{
+	"command": true,
+	"Stdout":  true,
+	"Stderr":  true,
+}
+
+func redactValue(tool, key string, v any, inlineLimit int) any {
+	switch val := v.(type) {
+	case string:
+		if isSecretKey(key) || isSecretValue(val) {
+			return "[REDACTED]"
+		}
+		if scannedTextFields[key] {
+			val = redactSecretsInText(val)
+		}
+		if largeBodyFields[key] && inlineLimit > 0 && len(val) > inlineLimit {
+			return hashBody(val)
+		}
+		return val
+	case map[string]any:
+		return redact(tool, val, inlineLimit)
+	case []any:
+		items := make([]any, len(val))
+		for i, item := range val {
+			items[i] = redactValue(tool, key, item, inlineLimit)
+		}
+		return items
+	default:
+		return v
+	}
+}
+
+func isSecretKey(key string) bool {
+	for _, re := range secretKeyPatterns {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSecretValue(value string) bool {
+	for _, re := range secretValuePatterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func hashBody(body string) map[string]any {
+	sum := sha256.Sum256([]byte(body))
+	return map[string]any{
+		"sha256": fmt.Sprintf("%x", sum),
+		"size":   len(body),
+	}
+}
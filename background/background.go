@@ -0,0 +1,179 @@
+// Package background tracks the process groups RunShellCommandTool leaves
+// running after a command returns (e.g. `long_job &`), so a later tool call
+// — possibly in a different chat turn — can list, signal, wait on, or read
+// the output of a group it didn't start.
+package background
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Group is one tracked process group left running after its originating
+// RunShellCommandTool call returned.
+type Group struct {
+	PGID       int       `json:"pgid"`
+	Command    string    `json:"command"`
+	Directory  string    `json:"directory"`
+	StartedAt  time.Time `json:"started_at"`
+	StdoutPath string    `json:"stdout_path"`
+	StderrPath string    `json:"stderr_path"`
+}
+
+// Registry is the on-disk-backed set of tracked groups for a project, so
+// groups survive across chat turns (and process restarts) until they're
+// waited on or reaped.
+type Registry struct {
+	mu     sync.Mutex
+	path   string
+	Groups map[int]*Group
+}
+
+// NewRegistry loads any previously persisted groups from stateFile. A
+// missing or unreadable state file just starts empty.
+func NewRegistry(stateFile string) *Registry {
+	r := &Registry{path: stateFile, Groups: make(map[int]*Group)}
+	if data, err := os.ReadFile(stateFile); err == nil {
+		_ = json.Unmarshal(data, &r.Groups)
+	}
+	return r
+}
+
+// Track records g and persists the registry.
+func (r *Registry) Track(g *Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Groups[g.PGID] = g
+	return r.save()
+}
+
+// Remove drops pgid from the registry (the group has been waited on, or no
+// longer exists) and persists the change.
+func (r *Registry) Remove(pgid int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.Groups, pgid)
+	return r.save()
+}
+
+// Get returns the tracked group for pgid, if any.
+func (r *Registry) Get(pgid int) (*Group, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.Groups[pgid]
+	return g, ok
+}
+
+// List returns every tracked group.
+func (r *Registry) List() []*Group {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]*Group, 0, len(r.Groups))
+	for _, g := range r.Groups {
+		out = append(out, g)
+	}
+	return out
+}
+
+func (r *Registry) save() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.Groups, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+// ChildPIDs enumerates the live PIDs belonging to process group pgid, by
+// scanning /proc/*/stat on Linux and falling back to `ps -o pid= -g pgid`
+// wherever /proc isn't available.
+func ChildPIDs(pgid int) ([]int, error) {
+	if pids, err := childPIDsProc(pgid); err == nil {
+		return pids, nil
+	}
+	return childPIDsPS(pgid)
+}
+
+func childPIDsProc(pgid int) ([]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		raw, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			continue
+		}
+		// The comm field (2nd field) is parenthesized and may itself
+		// contain spaces, so split after its closing paren rather than on
+		// whitespace from the start.
+		s := string(raw)
+		closeParen := strings.LastIndex(s, ")")
+		if closeParen < 0 {
+			continue
+		}
+		fields := strings.Fields(s[closeParen+1:])
+		if len(fields) < 3 {
+			continue
+		}
+		pgrp, err := strconv.Atoi(fields[2])
+		if err == nil && pgrp == pgid {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+func childPIDsPS(pgid int) ([]int, error) {
+	out, err := exec.Command("ps", "-o", "pid=", "-g", strconv.Itoa(pgid)).Output()
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, field := range strings.Fields(string(out)) {
+		if pid, err := strconv.Atoi(field); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// GracefulStop sends SIGTERM to the process group, waits up to grace for it
+// to exit, and only then escalates to SIGKILL.
+func GracefulStop(pgid int, grace time.Duration) error {
+	if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+		if err == syscall.ESRCH {
+			return nil
+		}
+		return fmt.Errorf("SIGTERM process group %d: %w", pgid, err)
+	}
+
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		if syscall.Kill(-pgid, 0) != nil {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if syscall.Kill(-pgid, 0) == nil {
+		if err := syscall.Kill(-pgid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+			return fmt.Errorf("SIGKILL process group %d: %w", pgid, err)
+		}
+	}
+	return nil
+}
@@ -6,13 +6,35 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"thoth/audit"
+	"thoth/background"
+	"thoth/policy"
 	"thoth/tools"
+	"thoth/tools/plugin"
 
 	"google.golang.org/genai"
 )
 
+// pluginConfigPath is where main looks for an optional plugin manifest
+// (see tools/plugin and plugins.example.yaml). Its absence is not an
+// error — thoth runs fine with only its built-in tools.
+const pluginConfigPath = "plugins.yaml"
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	runChat()
+}
+
+func runChat() {
 	ctx := context.Background()
 	apiKey := os.Getenv("GEMINI_API_KEY")
 	if apiKey == "" {
@@ -27,10 +49,18 @@ func main() {
 		log.Fatal(err)
 	}
 
-	toolRegistry := make(map[string]tools.Tool)
+	projectRoot, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	registry := buildRegistry(ctx, projectRoot)
+
+	sessionID := fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())
+	auditLogger := buildAuditLogger(sessionID)
 
 	var functionDeclarations []*genai.FunctionDeclaration
-	for _, tool := range toolRegistry {
+	for _, tool := range registry.List() {
 		functionDeclarations = append(functionDeclarations, tool.Declaration())
 	}
 
@@ -65,6 +95,8 @@ func main() {
 			continue
 		}
 
+		turnID := auditLogger.NextTurn()
+
 	toolCallLoop:
 		for {
 			if resp == nil || len(resp.Candidates) == 0 {
@@ -87,8 +119,10 @@ func main() {
 						var toolResult map[string]any
 						var toolErr error
 
-						if tool, ok := toolRegistry[call.Name]; ok {
-							toolResult, toolErr = tool.Execute(ctx, call.Args)
+						if tool, ok := registry.Lookup(call.Name); ok {
+							toolResult, toolErr = auditLogger.Record(call.Name, turnID, call.Args, func() (map[string]any, error) {
+								return tool.Execute(ctx, call.Args)
+							})
 						} else {
 							toolErr = fmt.Errorf("unknown tool: %s", call.Name)
 						}
@@ -137,3 +171,78 @@ func main() {
 		log.Printf("Error reading input: %v", err)
 	}
 }
+
+// cliConfirmer is the RunPolicy.Confirmer this binary ships by default:
+// it asks the user on stdin/stdout before a command matching a dangerous
+// pattern runs, rather than auto-approving everything (the default when
+// no Confirmer is configured at all).
+type cliConfirmer struct{}
+
+func (cliConfirmer) Confirm(ctx context.Context, command, reason string) (bool, error) {
+	fmt.Printf("\nThe model wants to run a command that %s:\n  %s\nAllow it? [y/N] ", reason, command)
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return false, scanner.Err()
+	}
+	answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	return answer == "y" || answer == "yes", nil
+}
+
+// buildAuditLogger picks the configured sink — an HTTP endpoint
+// (THOTH_AUDIT_HTTP), a file (THOTH_AUDIT_LOG), or stderr if neither is
+// set — and returns a Logger that records every tool call to it.
+func buildAuditLogger(sessionID string) *audit.Logger {
+	if url := os.Getenv("THOTH_AUDIT_HTTP"); url != "" {
+		return audit.NewLogger(audit.NewHTTPSink(url), sessionID)
+	}
+	if path := os.Getenv("THOTH_AUDIT_LOG"); path != "" {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			log.Printf("Error opening audit log %s, falling back to stderr: %v", path, err)
+		} else {
+			return audit.NewLogger(audit.NewFileSink(f), sessionID)
+		}
+	}
+	return audit.NewLogger(audit.NewFileSink(os.Stderr), sessionID)
+}
+
+// buildRegistry registers the built-in tools plus, if pluginConfigPath
+// exists, every external tool it describes.
+func buildRegistry(ctx context.Context, projectRoot string) *tools.Registry {
+	registry := tools.NewRegistry()
+
+	backgroundRegistry := background.NewRegistry(filepath.Join(projectRoot, ".thoth", "background.json"))
+
+	registry.Register(&tools.ReadManyFilesTool{ProjectRoot: projectRoot})
+	registry.Register(&tools.RunShellCommandTool{
+		ProjectRoot: projectRoot,
+		Policy: &policy.RunPolicy{
+			// Fork bombs are rejected outright rather than merely
+			// flagged dangerous: there's no command a confirmation
+			// prompt should ever approve here.
+			Deny:      []string{`^:\(\)\{.*:\|:.*\};:`},
+			Confirmer: cliConfirmer{},
+		},
+		Background: backgroundRegistry,
+	})
+	registry.Register(&tools.WatchFilesTool{ProjectRoot: projectRoot})
+	registry.Register(&tools.ManageBackgroundProcessTool{Registry: backgroundRegistry})
+
+	if _, err := os.Stat(pluginConfigPath); err != nil {
+		return registry
+	}
+	cfg, err := plugin.LoadConfig(pluginConfigPath)
+	if err != nil {
+		log.Printf("Error loading plugin config %s: %v", pluginConfigPath, err)
+		return registry
+	}
+	plugins, err := tools.LoadPlugins(ctx, cfg)
+	if err != nil {
+		log.Printf("Error loading plugins from %s: %v", pluginConfigPath, err)
+		return registry
+	}
+	for _, p := range plugins {
+		registry.Register(p)
+	}
+	return registry
+}
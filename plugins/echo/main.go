@@ -0,0 +1,69 @@
+// Command echo is a reference implementation of the thoth plugin stdio
+// transport (see tools/plugin): it registers one tool, "echo", that returns
+// whatever text it's given. It exists to prove the transport works without
+// depending on the rest of this module.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type request struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type response struct {
+	ID     int         `json:"id"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+var declaration = map[string]any{
+	"name":        "echo",
+	"description": "Returns the `text` argument unchanged. Reference plugin for the stdio transport.",
+	"parameters": map[string]any{
+		"type": "OBJECT",
+		"properties": map[string]any{
+			"text": map[string]any{"type": "STRING", "description": "Text to echo back."},
+		},
+		"required": []string{"text"},
+	},
+}
+
+func main() {
+	in := bufio.NewScanner(os.Stdin)
+	in.Buffer(make([]byte, 0, 64*1024), 8<<20)
+	out := json.NewEncoder(os.Stdout)
+
+	for in.Scan() {
+		var req request
+		if err := json.Unmarshal(in.Bytes(), &req); err != nil {
+			continue
+		}
+		out.Encode(handle(req))
+	}
+}
+
+func handle(req request) response {
+	switch req.Method {
+	case "describe":
+		return response{ID: req.ID, Result: declaration}
+	case "execute":
+		var params struct {
+			Args struct {
+				Text string `json:"text"`
+			} `json:"args"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return response{ID: req.ID, Error: fmt.Sprintf("invalid args: %v", err)}
+		}
+		return response{ID: req.ID, Result: map[string]any{"text": params.Args.Text}}
+	default:
+		return response{ID: req.ID, Error: "unknown method: " + req.Method}
+	}
+}
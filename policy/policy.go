@@ -0,0 +1,286 @@
+// Package policy defines the guardrails RunShellCommandTool enforces on
+// every command it executes: which commands are allowed at all, what
+// resources they may consume, which environment variables they see, which
+// directories they may run in, and which of them need an interactive
+// confirmation first.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultEnvAllowlist is the environment handed to a command when no
+// explicit EnvAllowlist is configured.
+var DefaultEnvAllowlist = []string{"PATH", "HOME", "LANG"}
+
+// DefaultDangerousPatterns flags commands a Confirmer should ask about
+// before they run, even if they pass the allow/deny lists.
+var DefaultDangerousPatterns = []string{
+	`rm\s+-rf`,
+	`curl[^|]*\|\s*sh`,
+	`wget[^|]*\|\s*sh`,
+	`git\s+push\s+.*--force`,
+	`:\(\)\{.*:\|:.*\};:`, // fork bomb
+}
+
+// Confirmer is asked to approve a command that matched a dangerous pattern
+// before it runs. Implementations typically prompt a human via the UI.
+type Confirmer interface {
+	Confirm(ctx context.Context, command, reason string) (bool, error)
+}
+
+// Violation is returned when a command is rejected by policy, so the model
+// can see exactly why and adjust instead of retrying blindly.
+type Violation struct {
+	Reason  string
+	Command string
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("command rejected by policy (%s): %s", v.Reason, v.Command)
+}
+
+// RunPolicy is the set of guardrails a RunShellCommandTool enforces on every
+// invocation.
+type RunPolicy struct {
+	// Allow, if non-empty, restricts commands to those matching at least
+	// one entry. An entry starting with '^' is a regexp matched against
+	// the full command string; otherwise it's matched exactly (not as a
+	// prefix) against the command's first shell word, e.g. "git" allows
+	// "git status" but not "github-cli status".
+	Allow []string
+	// Deny rejects any command matching an entry, using the same syntax as
+	// Allow. Deny is checked after Allow and always wins.
+	Deny []string
+
+	// MaxOutputBytes caps how much of stdout/stderr is kept; the rest is
+	// dropped with a truncation marker appended. Zero means unlimited.
+	MaxOutputBytes int
+
+	// MaxCPUSeconds and MaxMemoryBytes cap the command's resource usage via
+	// the subshell's own `ulimit` (exec.Cmd has no portable rlimit hook, so
+	// the limit is applied inside the process being started rather than by
+	// the parent). Zero means unlimited.
+	MaxCPUSeconds  uint64
+	MaxMemoryBytes uint64
+
+	// MaxWallClock caps how long the command may run before it's sent
+	// SIGTERM. Zero means no wall-clock cap beyond ctx's own deadline.
+	MaxWallClock time.Duration
+
+	// ShutdownGrace is how long a cancelled command's process group gets
+	// after SIGTERM before it's escalated to SIGKILL. Defaults to 2s when
+	// zero.
+	ShutdownGrace time.Duration
+
+	// EnvAllowlist is the set of environment variable names passed through
+	// to the command. Defaults to DefaultEnvAllowlist when nil.
+	EnvAllowlist []string
+
+	// DangerousPatterns are regexps checked against the full command; a
+	// match requires Confirmer's approval before the command runs. Defaults
+	// to DefaultDangerousPatterns when nil.
+	DangerousPatterns []string
+	Confirmer         Confirmer
+
+	allowRe, denyRe, dangerRe []*regexp.Regexp
+	compiled                  bool
+}
+
+func (p *RunPolicy) compile() {
+	if p.compiled {
+		return
+	}
+	compileList := func(entries []string) []*regexp.Regexp {
+		var res []*regexp.Regexp
+		for _, e := range entries {
+			if strings.HasPrefix(e, "^") {
+				if re, err := regexp.Compile(e); err == nil {
+					res = append(res, re)
+				}
+			}
+		}
+		return res
+	}
+	p.allowRe = compileList(p.Allow)
+	p.denyRe = compileList(p.Deny)
+
+	dangerous := p.DangerousPatterns
+	if dangerous == nil {
+		dangerous = DefaultDangerousPatterns
+	}
+	for _, pat := range dangerous {
+		if re, err := regexp.Compile(pat); err == nil {
+			p.dangerRe = append(p.dangerRe, re)
+		}
+	}
+	p.compiled = true
+}
+
+func firstWord(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func matchesAny(command string, literals []string, regexes []*regexp.Regexp) bool {
+	word := firstWord(command)
+	for _, lit := range literals {
+		if strings.HasPrefix(lit, "^") {
+			continue
+		}
+		if word == lit {
+			return true
+		}
+	}
+	for _, re := range regexes {
+		if re.MatchString(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCommand enforces the allow/deny lists, returning a *Violation if the
+// command isn't permitted.
+func (p *RunPolicy) CheckCommand(command string) error {
+	p.compile()
+	if len(p.Allow) > 0 && !matchesAny(command, p.Allow, p.allowRe) {
+		return &Violation{Reason: "not in allowlist", Command: command}
+	}
+	if matchesAny(command, p.Deny, p.denyRe) {
+		return &Violation{Reason: "matches denylist", Command: command}
+	}
+	return nil
+}
+
+// IsDangerous reports whether command matches one of the DangerousPatterns.
+func (p *RunPolicy) IsDangerous(command string) bool {
+	p.compile()
+	for _, re := range p.dangerRe {
+		if re.MatchString(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// Confirm runs command through the configured Confirmer if it's dangerous.
+// A command that isn't dangerous, or a policy with no Confirmer configured,
+// is always approved.
+func (p *RunPolicy) Confirm(ctx context.Context, command string) error {
+	if !p.IsDangerous(command) || p.Confirmer == nil {
+		return nil
+	}
+	ok, err := p.Confirmer.Confirm(ctx, command, "matches a dangerous command pattern")
+	if err != nil {
+		return fmt.Errorf("confirm %q: %w", command, err)
+	}
+	if !ok {
+		return &Violation{Reason: "rejected by user confirmation", Command: command}
+	}
+	return nil
+}
+
+// Env builds the environment passed to the command: everything in environ
+// whose name is in the allowlist (EnvAllowlist, or DefaultEnvAllowlist if
+// unset).
+func (p *RunPolicy) Env(environ []string) []string {
+	allow := p.EnvAllowlist
+	if allow == nil {
+		allow = DefaultEnvAllowlist
+	}
+	allowed := make(map[string]bool, len(allow))
+	for _, name := range allow {
+		allowed[name] = true
+	}
+	var out []string
+	for _, kv := range environ {
+		name, _, ok := strings.Cut(kv, "=")
+		if ok && allowed[name] {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// ResolveDirectory resolves dir (relative to root) and rejects it unless
+// it's a real subpath of root, following symlinks on both sides so a
+// symlink can't be used to escape the project root.
+func ResolveDirectory(root, dir string) (string, error) {
+	absRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("resolve project root: %w", err)
+	}
+	target := root
+	if dir != "" {
+		target = filepath.Join(root, dir)
+	}
+	absTarget, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return "", fmt.Errorf("resolve directory %q: %w", dir, err)
+	}
+	rel, err := filepath.Rel(absRoot, absTarget)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &Violation{Reason: "directory escapes project root", Command: dir}
+	}
+	return absTarget, nil
+}
+
+// WrapCommand prefixes command with `ulimit` calls for any configured
+// MaxCPUSeconds/MaxMemoryBytes, leaving it unchanged if neither is set.
+func (p *RunPolicy) WrapCommand(command string) string {
+	var prologue []string
+	if p.MaxCPUSeconds > 0 {
+		prologue = append(prologue, fmt.Sprintf("ulimit -t %d", p.MaxCPUSeconds))
+	}
+	if p.MaxMemoryBytes > 0 {
+		prologue = append(prologue, fmt.Sprintf("ulimit -v %d", p.MaxMemoryBytes/1024))
+	}
+	if len(prologue) == 0 {
+		return command
+	}
+	return strings.Join(prologue, "; ") + "; " + command
+}
+
+// TruncatingWriter caps the bytes kept from a stream, appending a marker
+// once the limit is hit instead of growing without bound.
+type TruncatingWriter struct {
+	Limit     int
+	buf       []byte
+	written   int
+	truncated bool
+}
+
+func (w *TruncatingWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	w.written += n
+	if w.Limit <= 0 {
+		w.buf = append(w.buf, p...)
+		return n, nil
+	}
+	if remaining := w.Limit - len(w.buf); remaining > 0 {
+		if remaining > len(p) {
+			remaining = len(p)
+		}
+		w.buf = append(w.buf, p[:remaining]...)
+	}
+	if len(w.buf) >= w.Limit && w.written > len(w.buf) {
+		w.truncated = true
+	}
+	return n, nil
+}
+
+func (w *TruncatingWriter) String() string {
+	if w.truncated {
+		return string(w.buf) + fmt.Sprintf("\n...[truncated, %d bytes total]", w.written)
+	}
+	return string(w.buf)
+}
@@ -0,0 +1,36 @@
+package policy
+
+import "testing"
+
+func TestCheckCommandFirstWordIsExactNotPrefix(t *testing.T) {
+	p := &RunPolicy{Allow: []string{"git"}}
+
+	if err := p.CheckCommand("git status"); err != nil {
+		t.Fatalf("expected git to be allowed, got %v", err)
+	}
+	if err := p.CheckCommand("github-cli status"); err == nil {
+		t.Fatal("expected github-cli to be rejected: Allow entries match the first word exactly, not as a prefix")
+	}
+}
+
+func TestCheckCommandRegexAllow(t *testing.T) {
+	p := &RunPolicy{Allow: []string{`^git (status|log)`}}
+
+	if err := p.CheckCommand("git status"); err != nil {
+		t.Fatalf("expected regex allow to match, got %v", err)
+	}
+	if err := p.CheckCommand("git push"); err == nil {
+		t.Fatal("expected git push to be rejected by the regex allowlist")
+	}
+}
+
+func TestCheckCommandDenyWinsAndIsExact(t *testing.T) {
+	p := &RunPolicy{Deny: []string{"rm"}}
+
+	if err := p.CheckCommand("rm -rf /"); err == nil {
+		t.Fatal("expected rm to be denied")
+	}
+	if err := p.CheckCommand("rmdir empty"); err != nil {
+		t.Fatalf("expected rmdir to be unaffected by a deny entry for rm, got %v", err)
+	}
+}
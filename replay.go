@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"thoth/audit"
+)
+
+// runReplay implements `thoth replay <log>`: it re-executes every recorded
+// tool call against a fresh project root, to reproduce agent behavior
+// deterministically for debugging. A call whose arguments were redacted by
+// the audit Logger (secrets, large file bodies) can't be replayed exactly,
+// so it's reported as skipped rather than silently re-run with the
+// redaction placeholder as its argument.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	root := fs.String("root", ".", "project root to replay the log against")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: thoth replay [-root DIR] <log>")
+	}
+	logPath := fs.Arg(0)
+
+	f, err := os.Open(logPath)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", logPath, err)
+	}
+	defer f.Close()
+
+	ctx := context.Background()
+	registry := buildRegistry(ctx, *root)
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 8<<20)
+	for scanner.Scan() {
+		var entry audit.Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			fmt.Printf("skip: invalid log entry: %v\n", err)
+			continue
+		}
+
+		tool, ok := registry.Lookup(entry.Tool)
+		if !ok {
+			fmt.Printf("skip %s (turn %d): tool not registered against %s\n", entry.Tool, entry.TurnID, *root)
+			continue
+		}
+		if containsRedacted(entry.Args) {
+			fmt.Printf("skip %s (turn %d): arguments were redacted in the log, cannot replay exactly\n", entry.Tool, entry.TurnID)
+			continue
+		}
+
+		result, err := tool.Execute(ctx, entry.Args)
+		if err != nil {
+			fmt.Printf("%s (turn %d): error: %v (recorded error: %s)\n", entry.Tool, entry.TurnID, err, entry.Error)
+			continue
+		}
+		fmt.Printf("%s (turn %d): %v\n", entry.Tool, entry.TurnID, result)
+	}
+	return scanner.Err()
+}
+
+// containsRedacted reports whether v (an args tree decoded from JSON)
+// still carries the Logger's "[REDACTED]" placeholder anywhere in it.
+func containsRedacted(v any) bool {
+	switch val := v.(type) {
+	case string:
+		return val == "[REDACTED]"
+	case map[string]any:
+		for _, vv := range val {
+			if containsRedacted(vv) {
+				return true
+			}
+		}
+	case []any:
+		for _, vv := range val {
+			if containsRedacted(vv) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+// Package ignore implements the .gitignore / .geminiignore exclusion rules
+// backing the file tools: a directory-aware composed ruleset, plus a set of
+// always-on default excludes (node_modules, .git, vendor, and friends).
+package ignore
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultExcludes are skipped regardless of any .gitignore/.geminiignore,
+// matching the noise every file tool should stay out of by default.
+var DefaultExcludes = []string{
+	".git", "node_modules", "vendor", "dist", "build",
+	"*.pyc", "*.o", "*.so", "*.dylib", "*.dll", "*.exe",
+	"*.jpg", "*.jpeg", "*.png", "*.gif", "*.bmp", "*.ico",
+	"*.zip", "*.tar", "*.gz", "*.7z", "*.pdf",
+}
+
+// Options controls which ignore sources a Matcher honors.
+type Options struct {
+	RespectGitIgnore    bool
+	RespectGeminiIgnore bool
+	UseDefaultExcludes  bool
+	ExtraExclude        []string // caller-supplied `exclude` globs, relative to Root
+}
+
+// rule is one line of a .gitignore/.geminiignore file, or one of the
+// built-in/caller-supplied globs.
+type rule struct {
+	pattern  string // without leading '/' or trailing '/'
+	negate   bool
+	dirOnly  bool
+	anchored bool // pattern must match from baseDir, not at any depth
+	baseDir  string // relative to Root; "" for Root itself
+}
+
+// Matcher answers whether a path under Root should be skipped, composing
+// .gitignore/.geminiignore files from Root down to the path's directory
+// with the built-in defaults and any caller-supplied exclude globs.
+type Matcher struct {
+	root  string
+	opts  Options
+	extra []rule
+	cache map[string][]rule // relative dir ("." for Root) -> rules that dir's ignore files contribute
+}
+
+// New builds a Matcher rooted at root. Ignore files are loaded lazily as
+// directories are visited.
+func New(root string, opts Options) *Matcher {
+	m := &Matcher{root: root, opts: opts, cache: make(map[string][]rule)}
+	for _, g := range opts.ExtraExclude {
+		m.extra = append(m.extra, compilePattern(g, ""))
+	}
+	if opts.UseDefaultExcludes {
+		for _, g := range DefaultExcludes {
+			m.extra = append(m.extra, compilePattern(g, ""))
+		}
+	}
+	return m
+}
+
+// ShouldSkip reports whether relPath (relative to Root) should be excluded.
+// It composes ignore files from Root down to relPath's directory, so a
+// nested .gitignore/.geminiignore can both add to and negate its parents'
+// rules.
+func (m *Matcher) ShouldSkip(relPath string, isDir bool) bool {
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+	relDir := filepath.ToSlash(filepath.Dir(relPath))
+	if relDir == "." && relPath == "." {
+		return false
+	}
+
+	skip := false
+	for _, r := range m.extra {
+		if r.matches(relPath, isDir) {
+			skip = !r.negate
+		}
+	}
+	for _, dir := range ancestry(relDir) {
+		for _, r := range m.rulesForDir(dir) {
+			if r.matches(relPath, isDir) {
+				skip = !r.negate
+			}
+		}
+	}
+	return skip
+}
+
+// Walk visits every file and directory under Root with filepath.WalkDir,
+// pruning ignored directories (SkipDir) instead of descending into them and
+// filtering afterward.
+func (m *Matcher) Walk(fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(m.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(path, d, err)
+		}
+		if path == m.root {
+			return fn(path, d, nil)
+		}
+		rel, relErr := filepath.Rel(m.root, path)
+		if relErr != nil {
+			return relErr
+		}
+		if m.ShouldSkip(rel, d.IsDir()) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		return fn(path, d, nil)
+	})
+}
+
+// rulesForDir returns the ignore-file rules contributed by relDir itself
+// (not its ancestors), loading and caching them on first use. relDir is "."
+// for Root.
+func (m *Matcher) rulesForDir(relDir string) []rule {
+	if rules, ok := m.cache[relDir]; ok {
+		return rules
+	}
+	var rules []rule
+	absDir := filepath.Join(m.root, relDir)
+	baseDir := relDir
+	if baseDir == "." {
+		baseDir = ""
+	}
+	if m.opts.RespectGitIgnore {
+		rules = append(rules, loadIgnoreFile(filepath.Join(absDir, ".gitignore"), baseDir)...)
+	}
+	if m.opts.RespectGeminiIgnore {
+		rules = append(rules, loadIgnoreFile(filepath.Join(absDir, ".geminiignore"), baseDir)...)
+	}
+	m.cache[relDir] = rules
+	return rules
+}
+
+// ancestry returns relDir's ancestors from Root down to relDir itself,
+// inclusive, so rules are applied outermost-first and later (more nested,
+// or later in the same file) rules can override earlier ones.
+func ancestry(relDir string) []string {
+	if relDir == "." || relDir == "" {
+		return []string{"."}
+	}
+	var dirs []string
+	for d := relDir; ; {
+		dirs = append(dirs, d)
+		parent := filepath.Dir(d)
+		if parent == d || parent == "." {
+			break
+		}
+		d = parent
+	}
+	dirs = append(dirs, ".")
+	// dirs is currently leaf-first; reverse to root-first.
+	for i, j := 0, len(dirs)-1; i < j; i, j = i+1, j-1 {
+		dirs[i], dirs[j] = dirs[j], dirs[i]
+	}
+	return dirs
+}
+
+func loadIgnoreFile(path, baseDir string) []rule {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []rule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rules = append(rules, compilePattern(line, baseDir))
+	}
+	return rules
+}
+
+func compilePattern(line, baseDir string) rule {
+	negate := strings.HasPrefix(line, "!")
+	if negate {
+		line = line[1:]
+	}
+	dirOnly := strings.HasSuffix(line, "/")
+	line = strings.TrimSuffix(line, "/")
+	anchored := strings.HasPrefix(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	anchored = anchored || strings.Contains(line, "/")
+	return rule{pattern: line, negate: negate, dirOnly: dirOnly, anchored: anchored, baseDir: baseDir}
+}
+
+// matches reports whether r applies to relPath (relative to Root, slash
+// separated).
+func (r rule) matches(relPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		// A dir-only rule on a file still applies if the file lives under
+		// the matched directory; that's handled by Matcher pruning whole
+		// directories during Walk, and by checking ancestor directories in
+		// ShouldSkip's ancestry loop — so a bare file check here is a
+		// no-match, not a fallthrough.
+		return matchesAncestorDir(r, relPath)
+	}
+
+	rel := relPath
+	if r.baseDir != "" {
+		prefix := r.baseDir + "/"
+		if !strings.HasPrefix(relPath+"/", prefix) {
+			return false
+		}
+		rel = strings.TrimPrefix(relPath, prefix)
+	}
+	if rel == "" {
+		return false
+	}
+
+	if r.anchored {
+		ok, _ := filepath.Match(r.pattern, rel)
+		return ok
+	}
+
+	// Unanchored: match against any path segment / the basename, which
+	// covers the common "*.log", "node_modules" style patterns.
+	if ok, _ := filepath.Match(r.pattern, filepath.Base(rel)); ok {
+		return true
+	}
+	for _, seg := range strings.Split(rel, "/") {
+		if ok, _ := filepath.Match(r.pattern, seg); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAncestorDir reports whether relPath sits under a directory matched
+// by dir-only rule r, so a standalone ShouldSkip(file) call (outside of
+// Walk's directory pruning) still honors directory-scoped patterns like
+// "build/".
+func matchesAncestorDir(r rule, relPath string) bool {
+	dir := filepath.Dir(relPath)
+	if dir == "." {
+		return false
+	}
+	for _, seg := range ancestry(dir) {
+		if seg == "." {
+			continue
+		}
+		if (rule{pattern: r.pattern, anchored: r.anchored, baseDir: r.baseDir}).matches(seg, true) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShouldSkip_NestedGitignore(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "*.log\nbuild/\n")
+	writeFile(t, filepath.Join(root, "app.log"), "x")
+	writeFile(t, filepath.Join(root, "src", "main.go"), "x")
+	writeFile(t, filepath.Join(root, "src", "debug.log"), "x")
+	writeFile(t, filepath.Join(root, "build", "out.bin"), "x")
+
+	m := New(root, Options{RespectGitIgnore: true})
+
+	cases := map[string]bool{
+		"app.log":       true,
+		"src/main.go":   false,
+		"src/debug.log": true,
+		"build":         true,
+		"build/out.bin": true,
+	}
+	for rel, want := range cases {
+		isDir := rel == "build"
+		if got := m.ShouldSkip(rel, isDir); got != want {
+			t.Errorf("ShouldSkip(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+func TestShouldSkip_NegationOrdering(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "*.log\n")
+	writeFile(t, filepath.Join(root, "keep", ".gitignore"), "!important.log\n")
+	writeFile(t, filepath.Join(root, "keep", "important.log"), "x")
+	writeFile(t, filepath.Join(root, "keep", "other.log"), "x")
+
+	m := New(root, Options{RespectGitIgnore: true})
+
+	if m.ShouldSkip("keep/important.log", false) {
+		t.Error("keep/important.log should be un-ignored by the nested negation")
+	}
+	if !m.ShouldSkip("keep/other.log", false) {
+		t.Error("keep/other.log should still be ignored by the root .gitignore")
+	}
+}
+
+func TestShouldSkip_DefaultExcludes(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "node_modules", "pkg", "index.js"), "x")
+	writeFile(t, filepath.Join(root, "main.go"), "x")
+
+	m := New(root, Options{UseDefaultExcludes: true})
+
+	if !m.ShouldSkip("node_modules/pkg/index.js", false) {
+		t.Error("expected node_modules contents to be skipped by default")
+	}
+	if m.ShouldSkip("main.go", false) {
+		t.Error("main.go should not be skipped")
+	}
+}
+
+func TestShouldSkip_ExtraExclude(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "secret.env"), "x")
+
+	m := New(root, Options{ExtraExclude: []string{"*.env"}})
+
+	if !m.ShouldSkip("secret.env", false) {
+		t.Error("expected secret.env to be skipped by the caller-supplied exclude glob")
+	}
+}
+
+func TestWalk_PrunesIgnoredDirectories(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "build/\n")
+	writeFile(t, filepath.Join(root, "build", "out.bin"), "x")
+	writeFile(t, filepath.Join(root, "src", "main.go"), "x")
+
+	m := New(root, Options{RespectGitIgnore: true})
+
+	var visited []string
+	err := m.Walk(func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			rel, _ := filepath.Rel(root, path)
+			visited = append(visited, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rel := range visited {
+		if rel == "build/out.bin" {
+			t.Error("expected build/ to be pruned, but build/out.bin was visited")
+		}
+	}
+}
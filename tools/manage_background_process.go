@@ -0,0 +1,215 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"thoth/background"
+
+	"google.golang.org/genai"
+)
+
+const defaultTailBytes = 8192
+
+// ManageBackgroundProcessTool operates on the process groups
+// RunShellCommandTool hands off when it returns but some of the group's
+// members are still running.
+type ManageBackgroundProcessTool struct {
+	Registry *background.Registry
+}
+
+func (t *ManageBackgroundProcessTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        "manage_background_process",
+		Description: "Lists, signals, waits on, or reads the output of a process group that run_shell_command left running in the background. Groups are identified by the `pgid` run_shell_command returned in `Process Group PGID`.",
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"action": {
+					Type:        genai.TypeString,
+					Description: "Required. One of: list, signal, wait, read_output.",
+				},
+				"pgid": {
+					Type:        genai.TypeNumber,
+					Description: "Required for signal/wait/read_output. The process group id to act on.",
+				},
+				"signal": {
+					Type:        genai.TypeString,
+					Description: "For action=signal. Signal name, e.g. TERM, KILL, INT. Defaults to TERM.",
+				},
+				"timeout_ms": {
+					Type:        genai.TypeNumber,
+					Description: "For action=wait. Milliseconds to wait for the group to exit before giving up. Defaults to 30000.",
+				},
+				"stream": {
+					Type:        genai.TypeString,
+					Description: "For action=read_output. One of: stdout, stderr, both. Defaults to both.",
+				},
+				"tail_bytes": {
+					Type:        genai.TypeNumber,
+					Description: "For action=read_output. How many trailing bytes of each stream to return. Defaults to 8192.",
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
+
+func (t *ManageBackgroundProcessTool) Execute(ctx context.Context, args map[string]any) (map[string]any, error) {
+	action, _ := args["action"].(string)
+
+	switch action {
+	case "list":
+		return t.list(), nil
+	case "signal":
+		return t.signal(args)
+	case "wait":
+		return t.wait(ctx, args)
+	case "read_output":
+		return t.readOutput(args)
+	default:
+		return nil, fmt.Errorf("unknown action %q: want list, signal, wait, or read_output", action)
+	}
+}
+
+func (t *ManageBackgroundProcessTool) list() map[string]any {
+	groups := t.Registry.List()
+	out := make([]map[string]any, len(groups))
+	for i, g := range groups {
+		alive, _ := background.ChildPIDs(g.PGID)
+		out[i] = map[string]any{
+			"pgid":       g.PGID,
+			"command":    g.Command,
+			"directory":  g.Directory,
+			"started_at": g.StartedAt.Format(time.RFC3339),
+			"live_pids":  alive,
+		}
+	}
+	return map[string]any{"groups": out}
+}
+
+func (t *ManageBackgroundProcessTool) pgidArg(args map[string]any) (int, error) {
+	f, ok := args["pgid"].(float64)
+	if !ok {
+		return 0, fmt.Errorf("missing or invalid 'pgid' argument")
+	}
+	pgid := int(f)
+	if _, ok := t.Registry.Get(pgid); !ok {
+		return 0, fmt.Errorf("unknown process group %d", pgid)
+	}
+	return pgid, nil
+}
+
+func (t *ManageBackgroundProcessTool) signal(args map[string]any) (map[string]any, error) {
+	pgid, err := t.pgidArg(args)
+	if err != nil {
+		return nil, err
+	}
+	sigName, _ := args["signal"].(string)
+	if sigName == "" {
+		sigName = "TERM"
+	}
+	sig, ok := signalsByName[sigName]
+	if !ok {
+		return nil, fmt.Errorf("unknown signal %q", sigName)
+	}
+	if err := syscall.Kill(-pgid, sig); err != nil {
+		return nil, fmt.Errorf("signal process group %d: %w", pgid, err)
+	}
+	return map[string]any{"pgid": pgid, "signal": sigName}, nil
+}
+
+func (t *ManageBackgroundProcessTool) wait(ctx context.Context, args map[string]any) (map[string]any, error) {
+	pgid, err := t.pgidArg(args)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(floatArg(args["timeout_ms"], 30000)) * time.Millisecond
+	deadline := time.Now().Add(timeout)
+
+	for {
+		pids, _ := background.ChildPIDs(pgid)
+		if len(pids) == 0 {
+			_ = t.Registry.Remove(pgid)
+			return map[string]any{"pgid": pgid, "exited": true}, nil
+		}
+		if time.Now().After(deadline) {
+			return map[string]any{"pgid": pgid, "exited": false, "live_pids": pids}, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+func (t *ManageBackgroundProcessTool) readOutput(args map[string]any) (map[string]any, error) {
+	pgid, err := t.pgidArg(args)
+	if err != nil {
+		return nil, err
+	}
+	g, _ := t.Registry.Get(pgid)
+
+	stream, _ := args["stream"].(string)
+	if stream == "" {
+		stream = "both"
+	}
+	tailBytes := int64(floatArg(args["tail_bytes"], defaultTailBytes))
+
+	result := map[string]any{"pgid": pgid}
+	if stream == "stdout" || stream == "both" {
+		result["stdout"], err = tailFile(g.StdoutPath, tailBytes)
+		if err != nil {
+			return nil, fmt.Errorf("read stdout for group %d: %w", pgid, err)
+		}
+	}
+	if stream == "stderr" || stream == "both" {
+		result["stderr"], err = tailFile(g.StderrPath, tailBytes)
+		if err != nil {
+			return nil, fmt.Errorf("read stderr for group %d: %w", pgid, err)
+		}
+	}
+	return result, nil
+}
+
+func tailFile(path string, n int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := int64(0)
+	if info.Size() > n {
+		offset = info.Size() - n
+	}
+	if _, err := f.Seek(offset, 0); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(f); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+var signalsByName = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+	"CONT": syscall.SIGCONT,
+	"STOP": syscall.SIGSTOP,
+}
@@ -0,0 +1,53 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the plugin manifest: one entry per external tool, describing
+// how to launch its subprocess.
+type Config struct {
+	Tools []ToolConfig `json:"tools" yaml:"tools"`
+}
+
+// ToolConfig describes a single external tool's subprocess.
+type ToolConfig struct {
+	Name      string   `json:"name" yaml:"name"`
+	Command   string   `json:"command" yaml:"command"`
+	Args      []string `json:"args" yaml:"args"`
+	TimeoutMs int      `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// LoadConfig reads a plugin manifest, choosing a YAML or JSON decoder by
+// the file's extension.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	default:
+		err = json.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse plugin config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Timeout returns the configured per-call timeout, defaulting to 30s.
+func (c ToolConfig) Timeout() time.Duration {
+	if c.TimeoutMs <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.TimeoutMs) * time.Millisecond
+}
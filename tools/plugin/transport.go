@@ -0,0 +1,236 @@
+// Package plugin implements the stdio JSON-RPC-style transport external
+// tools use: each external tool is a subprocess speaking newline-delimited
+// JSON requests/responses on its stdin/stdout, so a tool can be written in
+// any language without the host recompiling.
+//
+// Wire format, one JSON object per line in both directions:
+//
+//	request:  {"id": 1, "method": "describe"}
+//	response: {"id": 1, "result": {"name": "...", "description": "...", "parameters": {...}}}
+//
+//	request:  {"id": 2, "method": "execute", "params": {"args": {...}}}
+//	response: {"id": 2, "result": {...}}           // on success
+//	response: {"id": 2, "error": "..."}             // on failure
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Request is one call sent to a plugin subprocess.
+type Request struct {
+	ID     int             `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is one reply read back from a plugin subprocess.
+type Response struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Client manages one plugin subprocess: writing requests to its stdin,
+// reading responses from its stdout, and respawning it if it dies.
+type Client struct {
+	Name    string
+	Command string
+	Args    []string
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	nextID  int
+	pending map[int]chan Response
+	exited  chan struct{}
+
+	// respawnMu serializes respawns, so two calls that both observe the
+	// same dead generation don't race spawn()ing a replacement process
+	// concurrently; only the first actually spawns, the second sees the
+	// generation has already moved on and no-ops.
+	respawnMu sync.Mutex
+}
+
+// Connect spawns the plugin subprocess and starts reading its responses.
+func Connect(name, command string, args []string, timeout time.Duration) (*Client, error) {
+	c := &Client{Name: name, Command: command, Args: args, Timeout: timeout}
+	if err := c.spawn(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) spawn() error {
+	cmd := exec.Command(c.Command, c.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("plugin %s: stdin pipe: %w", c.Name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("plugin %s: stdout pipe: %w", c.Name, err)
+	}
+	cmd.Stderr = nil
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("plugin %s: start: %w", c.Name, err)
+	}
+
+	exited := make(chan struct{})
+
+	c.mu.Lock()
+	c.cmd = cmd
+	c.stdin = stdin
+	c.pending = make(map[int]chan Response)
+	c.exited = exited
+	c.mu.Unlock()
+
+	go c.readLoop(stdout)
+	go func() {
+		// Capture exited (this generation's channel) rather than
+		// reading c.exited when this goroutine runs — by then a
+		// respawn may have already replaced it with a new one, and
+		// closing that would incorrectly mark the fresh process dead.
+		cmd.Wait()
+		close(exited)
+	}()
+	return nil
+}
+
+func (c *Client) readLoop(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 8<<20)
+	for scanner.Scan() {
+		var resp Response
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			continue
+		}
+		c.mu.Lock()
+		ch, ok := c.pending[resp.ID]
+		if ok {
+			delete(c.pending, resp.ID)
+		}
+		c.mu.Unlock()
+		if ok {
+			ch <- resp
+		}
+	}
+}
+
+// Call sends method/params and waits for the matching response, ctx's
+// deadline, or the plugin's process exiting — whichever comes first. A
+// dead plugin is respawned once before the call is reported as failed.
+func (c *Client) Call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	c.mu.Lock()
+	exitedCh := c.exited
+	c.mu.Unlock()
+
+	resp, err := c.call(ctx, method, params)
+	if err != nil {
+		select {
+		case <-exitedCh:
+			if respawnErr := c.respawnOnce(exitedCh); respawnErr != nil {
+				return nil, fmt.Errorf("plugin %s: respawn after death: %w", c.Name, respawnErr)
+			}
+			return c.call(ctx, method, params)
+		default:
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// respawnOnce replaces the subprocess behind staleExited, unless another
+// caller has already done so (c.exited no longer matches staleExited) —
+// so two Call()s that both observe the same dead generation only spawn
+// one replacement instead of racing on c.cmd/c.stdin.
+func (c *Client) respawnOnce(staleExited chan struct{}) error {
+	c.respawnMu.Lock()
+	defer c.respawnMu.Unlock()
+
+	c.mu.Lock()
+	current := c.exited
+	c.mu.Unlock()
+	if current != staleExited {
+		return nil
+	}
+	return c.spawn()
+}
+
+func (c *Client) call(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	var raw json.RawMessage
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: marshal params: %w", c.Name, err)
+		}
+		raw = b
+	}
+
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	ch := make(chan Response, 1)
+	c.pending[id] = ch
+	stdin := c.stdin
+	exited := c.exited
+	c.mu.Unlock()
+
+	line, err := json.Marshal(Request{ID: id, Method: method, Params: raw})
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: marshal request: %w", c.Name, err)
+	}
+	line = append(line, '\n')
+
+	if _, err := stdin.Write(line); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("plugin %s: write request: %w", c.Name, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	case <-exited:
+		return nil, fmt.Errorf("plugin %s: process exited", c.Name)
+	case resp := <-ch:
+		if resp.Error != "" {
+			return nil, fmt.Errorf("plugin %s: %s", c.Name, resp.Error)
+		}
+		return resp.Result, nil
+	}
+}
+
+// Close terminates the plugin subprocess.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	cmd := c.cmd
+	stdin := c.stdin
+	c.mu.Unlock()
+	if stdin != nil {
+		stdin.Close()
+	}
+	if cmd != nil && cmd.Process != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
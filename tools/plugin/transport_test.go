@@ -0,0 +1,125 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestMain lets this test binary double as the plugin subprocess the
+// tests below spawn: when GO_WANT_HELPER_PROCESS is set, it behaves as
+// an NDJSON stdio plugin (shaped by GO_HELPER_MODE) instead of running
+// the test suite — the same technique os/exec's own tests use to get a
+// real, killable child process without shipping a second binary.
+func TestMain(m *testing.M) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") == "1" {
+		runHelperPlugin(os.Getenv("GO_HELPER_MODE"))
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runHelperPlugin answers requests on stdin/stdout like a real plugin.
+// "slow" sleeps before every reply (for timeout/cancellation tests);
+// "die-after-one" answers exactly one request and then exits, simulating
+// a plugin that crashes mid-session.
+func runHelperPlugin(mode string) {
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	answered := 0
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+		if mode == "die-after-one" && answered >= 1 {
+			return
+		}
+		if mode == "slow" {
+			time.Sleep(2 * time.Second)
+		}
+		b, err := json.Marshal(Response{ID: req.ID, Result: req.Params})
+		if err != nil {
+			continue
+		}
+		os.Stdout.Write(append(b, '\n'))
+		answered++
+	}
+}
+
+func newHelperClient(t *testing.T, mode string, timeout time.Duration) *Client {
+	t.Helper()
+	t.Setenv("GO_WANT_HELPER_PROCESS", "1")
+	t.Setenv("GO_HELPER_MODE", mode)
+	c, err := Connect("helper", os.Args[0], nil, timeout)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestCallRoundTrip(t *testing.T) {
+	c := newHelperClient(t, "", 0)
+
+	raw, err := c.Call(context.Background(), "describe", map[string]any{"ping": "pong"})
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if got["ping"] != "pong" {
+		t.Fatalf("got %v, want echoed params", got)
+	}
+}
+
+func TestCallReconnectsAfterPluginDeath(t *testing.T) {
+	c := newHelperClient(t, "die-after-one", 0)
+
+	if _, err := c.Call(context.Background(), "describe", nil); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	// The helper answered once and will exit without replying to the
+	// next request; Call must notice the dead process and respawn it
+	// transparently rather than hanging or returning a stale error.
+	if _, err := c.Call(context.Background(), "describe", nil); err != nil {
+		t.Fatalf("call after respawn: %v", err)
+	}
+}
+
+func TestCallTimesOut(t *testing.T) {
+	c := newHelperClient(t, "slow", 100*time.Millisecond)
+
+	_, err := c.Call(context.Background(), "describe", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got err %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestCallPropagatesCancellation(t *testing.T) {
+	c := newHelperClient(t, "slow", 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := c.Call(ctx, "describe", nil)
+		errCh <- err
+	}()
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got err %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Call did not return after ctx was cancelled")
+	}
+}
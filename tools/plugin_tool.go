@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"thoth/tools/plugin"
+
+	"google.golang.org/genai"
+)
+
+// PluginTool adapts an external tool, running as a subprocess and speaking
+// the plugin stdio transport, to the Tool interface.
+type PluginTool struct {
+	client *plugin.Client
+	decl   *genai.FunctionDeclaration
+}
+
+// LoadPlugins spawns every tool described by cfg, calling its "describe" RPC
+// to learn its Declaration up front.
+func LoadPlugins(ctx context.Context, cfg *plugin.Config) ([]*PluginTool, error) {
+	tools := make([]*PluginTool, 0, len(cfg.Tools))
+	for _, tc := range cfg.Tools {
+		client, err := plugin.Connect(tc.Name, tc.Command, tc.Args, tc.Timeout())
+		if err != nil {
+			return nil, fmt.Errorf("connect plugin %s: %w", tc.Name, err)
+		}
+
+		raw, err := client.Call(ctx, "describe", nil)
+		if err != nil {
+			client.Close()
+			return nil, fmt.Errorf("describe plugin %s: %w", tc.Name, err)
+		}
+
+		var decl genai.FunctionDeclaration
+		if err := json.Unmarshal(raw, &decl); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("describe plugin %s: invalid declaration: %w", tc.Name, err)
+		}
+		if decl.Name == "" {
+			decl.Name = tc.Name
+		}
+
+		tools = append(tools, &PluginTool{client: client, decl: &decl})
+	}
+	return tools, nil
+}
+
+func (t *PluginTool) Declaration() *genai.FunctionDeclaration {
+	return t.decl
+}
+
+func (t *PluginTool) Execute(ctx context.Context, args map[string]any) (map[string]any, error) {
+	raw, err := t.client.Call(ctx, "execute", map[string]any{"args": args})
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]any
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid execute result: %w", t.decl.Name, err)
+	}
+	return result, nil
+}
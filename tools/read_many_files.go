@@ -1,16 +1,42 @@
 package tools
 
 import (
-	"bytes"
+	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"unicode/utf8"
+
+	"thoth/tools/ignore"
 
 	"google.golang.org/genai"
 )
 
+const (
+	defaultMaxBytesPerFile = 1 << 20 // 1 MiB
+	defaultMaxTotalBytes   = 8 << 20 // 8 MiB
+	sniffLen               = 512
+)
+
+// textishMimePrefixes lists MIME types that DetectContentType reports as
+// non "text/*" but that are still safe to include as text content.
+var textishMimePrefixes = []string{
+	"application/json",
+	"application/xml",
+	"application/javascript",
+	"application/x-yaml",
+	"application/toml",
+}
+
 type ReadManyFilesTool struct {
 	ProjectRoot string
 }
@@ -18,13 +44,13 @@ type ReadManyFilesTool struct {
 func (t *ReadManyFilesTool) Declaration() *genai.FunctionDeclaration {
 	return &genai.FunctionDeclaration{
 		Name:        "read_many_files",
-		Description: "Reads content from multiple files specified by paths or glob patterns within a configured target directory. For text files, it concatenates their content into a single string, separated by '--- {filePath} ---'. Binary files (images, PDFs, etc.) will be listed by their path with a note that their content is not included. Glob patterns like 'src/**/*.js' are supported. Paths are relative to the project root. Avoid using for single files if a more specific single-file reading tool is available, unless the user specifically requests to process a list containing just one file via this tool.",
+		Description: "Reads content from multiple files specified by paths or glob patterns within a configured target directory, streaming results in bounded chunks. For text files, it concatenates their content into a single string, separated by '--- {filePath} ---'. Binary files (images, PDFs, etc.) are reported as {path, mime, size, sha256} instead of their content. Glob patterns like 'src/**/*.js' are supported, as is `path@start:end` to fetch only a line range of a single file. Paths are relative to the project root. If the response is truncated by max_bytes_per_file/max_total_bytes, a `continuation_token` is returned; pass it back to resume where the previous call left off.",
 		Parameters: &genai.Schema{
 			Type: genai.TypeObject,
 			Properties: map[string]*genai.Schema{
 				"paths": {
 					Type:        genai.TypeArray,
-					Description: "Required. An array of glob patterns or paths relative to the tool's target directory. Examples: ['src/**/*.ts'], ['README.md', 'docs/']",
+					Description: "Required. An array of glob patterns or paths relative to the tool's target directory. A path may be suffixed with `@start:end` to fetch only that 1-based line range. Examples: ['src/**/*.ts'], ['README.md', 'docs/'], ['main.go@10:40']",
 					Items:       &genai.Schema{Type: genai.TypeString},
 				},
 				"exclude": {
@@ -47,7 +73,7 @@ func (t *ReadManyFilesTool) Declaration() *genai.FunctionDeclaration {
 				},
 				"file_filtering_options": {
 					Type:        genai.TypeObject,
-					Description: "Whether to respect ignore patterns from .gitignore or .geminiignore (not fully implemented in this version)",
+					Description: "Whether to respect ignore patterns from .gitignore or .geminiignore.",
 					Properties: map[string]*genai.Schema{
 						"respect_gemini_ignore": {
 							Type:        genai.TypeBoolean,
@@ -59,83 +85,450 @@ func (t *ReadManyFilesTool) Declaration() *genai.FunctionDeclaration {
 						},
 					},
 				},
+				"max_bytes_per_file": {
+					Type:        genai.TypeNumber,
+					Description: "Optional. Caps how many bytes of a single file are read per call. Defaults to 1 MiB.",
+				},
+				"max_total_bytes": {
+					Type:        genai.TypeNumber,
+					Description: "Optional. Caps total bytes read across all files per call. Defaults to 8 MiB.",
+				},
+				"offset": {
+					Type:        genai.TypeNumber,
+					Description: "Optional. Index into the matched file list to start from. Usually left unset in favor of continuation_token.",
+				},
+				"continuation_token": {
+					Type:        genai.TypeString,
+					Description: "Optional. Token returned by a previous truncated call; resumes reading from exactly where it left off.",
+				},
 			},
 			Required: []string{"paths"},
 		},
 	}
 }
 
-func (t *ReadManyFilesTool) Execute(ctx context.Context, args map[string]any) (map[string]any, error) {
-	paths, ok := args["paths"].([]any)
+// continuationToken is an opaque cursor into the (paths-dependent) ordered
+// list of matched files, so a truncated call can resume exactly where it
+// stopped without the caller needing to track byte offsets itself.
+// ByteOffset resumes a whole-file read; LineOffset resumes a `path@start:end`
+// ranged read (a line number, since the underlying scanner can't be resumed
+// by byte offset alone without re-deriving line boundaries).
+type continuationToken struct {
+	FileIndex  int   `json:"file_index"`
+	ByteOffset int64 `json:"byte_offset,omitempty"`
+	LineOffset int   `json:"line_offset,omitempty"`
+}
+
+func encodeToken(t continuationToken) string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeToken(s string) (continuationToken, error) {
+	var t continuationToken
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, fmt.Errorf("invalid continuation_token: %w", err)
+	}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return t, fmt.Errorf("invalid continuation_token: %w", err)
+	}
+	return t, nil
+}
+
+// filePattern is a single entry from `paths`, optionally narrowed to a line
+// range via a trailing `@start:end`.
+type filePattern struct {
+	glob       string
+	startLine  int // 0 means "from the beginning"
+	endLine    int // 0 means "to the end"
+}
+
+func parseFilePattern(raw string) filePattern {
+	at := strings.LastIndex(raw, "@")
+	if at < 0 {
+		return filePattern{glob: raw}
+	}
+	rangePart := raw[at+1:]
+	start, end, ok := parseLineRange(rangePart)
 	if !ok {
+		return filePattern{glob: raw}
+	}
+	return filePattern{glob: raw[:at], startLine: start, endLine: end}
+}
+
+// buildIgnoreMatcher assembles the ignore.Matcher from this call's
+// file_filtering_options/useDefaultExcludes/exclude arguments, defaulting
+// every ignore source to on.
+func buildIgnoreMatcher(root string, args map[string]any, exclude []string) *ignore.Matcher {
+	respectGit := true
+	respectGemini := true
+	if ffo, ok := args["file_filtering_options"].(map[string]any); ok {
+		if v, ok := ffo["respect_git_ignore"].(bool); ok {
+			respectGit = v
+		}
+		if v, ok := ffo["respect_gemini_ignore"].(bool); ok {
+			respectGemini = v
+		}
+	}
+	useDefaults := true
+	if v, ok := args["useDefaultExcludes"].(bool); ok {
+		useDefaults = v
+	}
+	return ignore.New(root, ignore.Options{
+		RespectGitIgnore:    respectGit,
+		RespectGeminiIgnore: respectGemini,
+		UseDefaultExcludes:  useDefaults,
+		ExtraExclude:        exclude,
+	})
+}
+
+// matchGlobPath matches a slash-separated relative path against a pattern
+// that may contain "**" to mean "zero or more path segments", in addition
+// to the single-segment wildcards filepath.Match already supports.
+func matchGlobPath(pattern, relPath string) bool {
+	return matchGlobSegs(strings.Split(pattern, "/"), strings.Split(relPath, "/"))
+}
+
+func matchGlobSegs(patternSegs, pathSegs []string) bool {
+	if len(patternSegs) == 0 {
+		return len(pathSegs) == 0
+	}
+	if patternSegs[0] == "**" {
+		if matchGlobSegs(patternSegs[1:], pathSegs) {
+			return true
+		}
+		return len(pathSegs) > 0 && matchGlobSegs(patternSegs, pathSegs[1:])
+	}
+	if len(pathSegs) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(patternSegs[0], pathSegs[0]); !ok {
+		return false
+	}
+	return matchGlobSegs(patternSegs[1:], pathSegs[1:])
+}
+
+func parseLineRange(s string) (start, end int, ok bool) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	start, err1 := strconv.Atoi(parts[0])
+	end, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || start < 1 || end < start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+func (t *ReadManyFilesTool) Execute(ctx context.Context, args map[string]any) (map[string]any, error) {
+	rawPaths, ok := stringSlice(args["paths"])
+	if !ok || len(rawPaths) == 0 {
 		return nil, fmt.Errorf("missing or invalid 'paths' argument")
 	}
+	include, _ := stringSlice(args["include"])
+
+	maxBytesPerFile := int64(floatArg(args["max_bytes_per_file"], defaultMaxBytesPerFile))
+	maxTotalBytes := int64(floatArg(args["max_total_bytes"], defaultMaxTotalBytes))
 
-	var filePaths []string
-	for _, p := range paths {
-		pattern, ok := p.(string)
-		if !ok {
-			return nil, fmt.Errorf("invalid path pattern: %v", p)
+	startIndex := int(floatArg(args["offset"], 0))
+	var resumeOffset int64
+	var resumeLine int
+	if tok, ok := args["continuation_token"].(string); ok && tok != "" {
+		ct, err := decodeToken(tok)
+		if err != nil {
+			return nil, err
 		}
+		startIndex = ct.FileIndex
+		resumeOffset = ct.ByteOffset
+		resumeLine = ct.LineOffset
+	}
 
-		absPattern := filepath.Join(t.ProjectRoot, pattern)
+	type match struct {
+		absPath   string
+		startLine int
+		endLine   int
+	}
 
-		if strings.ContainsAny(pattern, "*?[]") {
-			matches, err := filepath.Glob(absPattern)
-			if err != nil {
-				return nil, fmt.Errorf("error globbing pattern %s: %w", pattern, err)
+	exclude, _ := stringSlice(args["exclude"])
+	matcher := buildIgnoreMatcher(t.ProjectRoot, args, exclude)
+
+	var matches []match
+	var globPatterns []filePattern
+	for _, raw := range append(append([]string(nil), rawPaths...), include...) {
+		fp := parseFilePattern(raw)
+		if strings.ContainsAny(fp.glob, "*?[]") {
+			globPatterns = append(globPatterns, fp)
+			continue
+		}
+		// A literal path was named explicitly; honor it even if it would
+		// otherwise be ignored by a glob expansion.
+		matches = append(matches, match{absPath: filepath.Join(t.ProjectRoot, fp.glob), startLine: fp.startLine, endLine: fp.endLine})
+	}
+
+	if len(globPatterns) > 0 {
+		walkErr := matcher.Walk(func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			rel, relErr := filepath.Rel(t.ProjectRoot, path)
+			if relErr != nil {
+				return nil
 			}
-			filePaths = append(filePaths, matches...)
-		} else {
-			filePaths = append(filePaths, absPattern)
+			rel = filepath.ToSlash(rel)
+			for _, fp := range globPatterns {
+				if matchGlobPath(fp.glob, rel) {
+					matches = append(matches, match{absPath: path, startLine: fp.startLine, endLine: fp.endLine})
+					break
+				}
+			}
+			return nil
+		})
+		if walkErr != nil {
+			return nil, fmt.Errorf("error walking %s: %w", t.ProjectRoot, walkErr)
 		}
 	}
 
 	seen := make(map[string]bool)
-	uniqueFilePaths := []string{}
-	for _, p := range filePaths {
-		if !seen[p] {
-			seen[p] = true
-
-			uniqueFilePaths = append(uniqueFilePaths, p)
+	var unique []match
+	for _, m := range matches {
+		if !seen[m.absPath] {
+			seen[m.absPath] = true
+			unique = append(unique, m)
 		}
 	}
 
-	var contentBuilder strings.Builder
-	for _, filePath := range uniqueFilePaths {
+	var content strings.Builder
+	var binaries []map[string]any
+	var totalWritten int64
+	var nextToken string
+
+	for i := startIndex; i < len(unique); i++ {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
 		}
 
-		fileInfo, err := os.Stat(filePath)
+		m := unique[i]
+		fileOffset := int64(0)
+		startLine := m.startLine
+		if i == startIndex {
+			fileOffset = resumeOffset
+			if resumeLine > 0 {
+				startLine = resumeLine
+			}
+		}
+
+		fileInfo, err := os.Stat(m.absPath)
 		if err != nil {
 			if os.IsNotExist(err) {
-				contentBuilder.WriteString(fmt.Sprintf("---%s (Not Found)---\n", filePath))
+				content.WriteString(fmt.Sprintf("---%s (Not Found)---\n", m.absPath))
 			} else {
-				contentBuilder.WriteString(fmt.Sprintf("---%s (Error: %v)---\n", filePath, err))
+				content.WriteString(fmt.Sprintf("---%s (Error: %v)---\n", m.absPath, err))
 			}
 			continue
 		}
-
 		if fileInfo.IsDir() {
-			contentBuilder.WriteString(fmt.Sprintf("---%s (Directory)---\n", filePath))
+			content.WriteString(fmt.Sprintf("---%s (Directory)---\n", m.absPath))
+			continue
+		}
+
+		f, err := os.Open(m.absPath)
+		if err != nil {
+			content.WriteString(fmt.Sprintf("---%s (Error reading: %v)---\n", m.absPath, err))
+			continue
+		}
+
+		isText, mime, err := sniffText(f)
+		if err != nil {
+			f.Close()
+			content.WriteString(fmt.Sprintf("---%s (Error reading: %v)---\n", m.absPath, err))
+			continue
+		}
+
+		if !isText {
+			sum, err := sha256File(f)
+			f.Close()
+			if err != nil {
+				content.WriteString(fmt.Sprintf("---%s (Error hashing: %v)---\n", m.absPath, err))
+				continue
+			}
+			binaries = append(binaries, map[string]any{
+				"path":   m.absPath,
+				"mime":   mime,
+				"size":   fileInfo.Size(),
+				"sha256": sum,
+			})
 			continue
 		}
 
-		data, err := os.ReadFile(filePath)
+		budget := maxBytesPerFile
+		if remaining := maxTotalBytes - totalWritten; remaining < budget {
+			budget = remaining
+		}
+
+		written, truncatedAt, nextLine, err := writeTextChunk(&content, f, m.absPath, fileOffset, budget, startLine, m.endLine)
+		f.Close()
 		if err != nil {
-			contentBuilder.WriteString(fmt.Sprintf("---%s (Error reading: %v)---\n", filePath, err))
+			content.WriteString(fmt.Sprintf("---%s (Error reading: %v)---\n", m.absPath, err))
 			continue
 		}
+		totalWritten += written
+
+		if truncatedAt >= 0 {
+			nextToken = encodeToken(continuationToken{FileIndex: i, ByteOffset: truncatedAt})
+			break
+		}
+		if nextLine > 0 {
+			nextToken = encodeToken(continuationToken{FileIndex: i, LineOffset: nextLine})
+			break
+		}
+		if totalWritten >= maxTotalBytes && i < len(unique)-1 {
+			nextToken = encodeToken(continuationToken{FileIndex: i + 1, ByteOffset: 0})
+			break
+		}
+	}
+
+	result := map[string]any{"content": content.String()}
+	if len(binaries) > 0 {
+		result["binaries"] = binaries
+	}
+	if nextToken != "" {
+		result["continuation_token"] = nextToken
+	}
+	return result, nil
+}
+
+// sniffText reads up to sniffLen bytes to classify the file, then rewinds f
+// so the caller can still read it from the start.
+func sniffText(f *os.File) (isText bool, mime string, err error) {
+	buf := make([]byte, sniffLen)
+	n, err := f.Read(buf)
+	if err != nil && n == 0 {
+		if err.Error() == "EOF" {
+			return true, "text/plain", nil
+		}
+		return false, "", err
+	}
+	buf = buf[:n]
+	mime = http.DetectContentType(buf)
+	if _, err := f.Seek(0, 0); err != nil {
+		return false, "", err
+	}
+	if strings.HasPrefix(mime, "text/") {
+		return true, mime, nil
+	}
+	for _, prefix := range textishMimePrefixes {
+		if strings.HasPrefix(mime, prefix) {
+			return true, mime, nil
+		}
+	}
+	// DetectContentType falls back to application/octet-stream for content
+	// it doesn't recognize; treat valid UTF-8 as text in that case.
+	if mime == "application/octet-stream" && utf8.Valid(buf) {
+		return true, "text/plain; charset=utf-8", nil
+	}
+	return false, mime, nil
+}
+
+func sha256File(f *os.File) (string, error) {
+	if _, err := f.Seek(0, 0); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := copyBuffered(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func copyBuffered(dst io.Writer, src *os.File) (int64, error) {
+	buf := make([]byte, 32*1024)
+	var total int64
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				return total, werr
+			}
+			total += int64(n)
+		}
+		if err != nil {
+			if err.Error() == "EOF" {
+				return total, nil
+			}
+			return total, err
+		}
+	}
+}
 
-		if bytes.ContainsRune(data, 0) {
-			contentBuilder.WriteString(fmt.Sprintf("---%s (Binary File, content not included)---\n", filePath))
-		} else {
-			contentBuilder.WriteString(fmt.Sprintf("---%s --- %s\n", filePath, string(data)))
+// writeTextChunk writes the file's content (optionally limited to a 1-based
+// line range) to content, honoring byteOffset/startLine as a resume point
+// and budget as a per-call cap on bytes written. It returns the number of
+// bytes written, and, if budget was hit before the file (or range) was
+// exhausted, either the byte offset (whole-file reads) or the next line
+// number (ranged reads) to resume from on the next call — whichever one
+// doesn't apply is -1/0 respectively.
+func writeTextChunk(content *strings.Builder, f *os.File, label string, byteOffset, budget int64, startLine, endLine int) (written int64, truncatedAt int64, nextLine int, err error) {
+	truncatedAt = -1
+
+	if startLine == 0 && endLine == 0 {
+		if byteOffset > 0 {
+			if _, err := f.Seek(byteOffset, 0); err != nil {
+				return 0, -1, 0, err
+			}
+		}
+		content.WriteString(fmt.Sprintf("--- %s ---\n", label))
+		buf := make([]byte, 32*1024)
+		var pos int64
+		for pos < budget {
+			toRead := int64(len(buf))
+			if remaining := budget - pos; remaining < toRead {
+				toRead = remaining
+			}
+			n, rerr := f.Read(buf[:toRead])
+			if n > 0 {
+				content.Write(buf[:n])
+				pos += int64(n)
+			}
+			if rerr != nil {
+				if rerr.Error() == "EOF" {
+					return pos, -1, 0, nil
+				}
+				return pos, -1, 0, rerr
+			}
 		}
+		// Budget hit: is there more file left?
+		if _, rerr := f.Read(buf[:1]); rerr == nil {
+			return pos, byteOffset + pos, 0, nil
+		}
+		return pos, -1, 0, nil
 	}
 
-	return map[string]any{"content": contentBuilder.String()}, nil
+	content.WriteString(fmt.Sprintf("--- %s (lines %d-%d) ---\n", label, startLine, endLine))
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if line < startLine {
+			continue
+		}
+		if line > endLine {
+			break
+		}
+		lineBytes := int64(len(scanner.Bytes())) + 1
+		if budget <= 0 || written+lineBytes > budget {
+			return written, -1, line, nil
+		}
+		content.Write(scanner.Bytes())
+		content.WriteByte('\n')
+		written += lineBytes
+	}
+	if err := scanner.Err(); err != nil {
+		return written, -1, 0, err
+	}
+	return written, -1, 0, nil
 }
@@ -1,24 +1,39 @@
 package tools
 
 import (
-	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"syscall"
+	"time"
+
+	"thoth/background"
+	"thoth/policy"
 
 	"google.golang.org/genai"
 )
 
+const defaultShutdownGrace = 2 * time.Second
+
+// RunShellCommandTool executes a shell command under a RunPolicy, which
+// constrains which commands may run, what resources and environment they
+// get, and which directories they may run in. Any process group still
+// alive when the command's own wait returns is handed off to Background so
+// `manage_background_process` can list, signal, wait on, or read its
+// output later.
 type RunShellCommandTool struct {
 	ProjectRoot string
+	Policy      *policy.RunPolicy
+	Background  *background.Registry
 }
 
 func (t *RunShellCommandTool) Declaration() *genai.FunctionDeclaration {
 	return &genai.FunctionDeclaration{
 		Name:        "run_shell_command",
-		Description: "This tool executes a given shell command as `bash -c <command>`. Command can start background processes using `&`. Command is executed as a subprocess that leads its own process group. Command process group can be terminated as `kill -- -PGID` or signaled as `kill -s SIGNAL -- -PGID`. The following information is returned: Command: Executed command. Directory: Directory (relative to project root) where command was executed, or `(root)`. Stdout: Output on stdout stream. Can be `(empty)` or partial on error and for any unwaited background processes. Stderr: Output on stderr stream. Can be `(empty)` or partial on error and for any unwaited background processes. Error: Error or `(none)` if no error was reported for the subprocess. Exit Code: Exit code or `(none)` if terminated by signal. Signal: Signal number or `(none)` if no signal was received. Background PIDs: List of background processes started or `(none)`. Process Group PGID: Process group started or `(none)`",
+		Description: "This tool executes a given shell command as `bash -c <command>`, subject to the configured run policy (command allow/denylist, resource limits, environment allowlist, and a directory jail under the project root). Command can start background processes using `&`. Command is executed as a subprocess that leads its own process group. Command process group can be terminated as `kill -- -PGID` or signaled as `kill -s SIGNAL -- -PGID`. The following information is returned: Command: Executed command. Directory: Directory (relative to project root) where command was executed, or `(root)`. Stdout: Output on stdout stream. Can be `(empty)` or partial on error and for any unwaited background processes. Stderr: Output on stderr stream. Can be `(empty)` or partial on error and for any unwaited background processes. Error: Error or `(none)` if no error was reported for the subprocess. Exit Code: Exit code or `(none)` if terminated by signal. Signal: Signal number or `(none)` if no signal was received. Background PIDs: List of background processes started or `(none)`. Process Group PGID: Process group started or `(none)`",
 		Parameters: &genai.Schema{
 			Type: genai.TypeObject,
 			Properties: map[string]*genai.Schema{
@@ -32,7 +47,7 @@ func (t *RunShellCommandTool) Declaration() *genai.FunctionDeclaration {
 				},
 				"directory": {
 					Type:        genai.TypeString,
-					Description: "(OPTIONAL) Directory to run the command in, if not the project root directory. Must be relative to the project root directory and must already exist.",
+					Description: "(OPTIONAL) Directory to run the command in, if not the project root directory. Must be relative to the project root directory, must already exist, and must resolve (after following symlinks) to a real subpath of the project root.",
 				},
 			},
 			Required: []string{"command"},
@@ -62,25 +77,63 @@ func (t *RunShellCommandTool) Execute(ctx context.Context, args map[string]any)
 
 	dir, _ := args["directory"].(string) // Optional
 
-	cmd := exec.CommandContext(ctx, "bash", "-c", cmdStr)
-	if dir != "" {
-		cmd.Dir = filepath.Join(t.ProjectRoot, dir) // Resolve relative to project root
-	} else {
-		cmd.Dir = t.ProjectRoot // Default to project root
+	pol := t.Policy
+	if pol == nil {
+		pol = &policy.RunPolicy{}
+	}
+
+	if err := pol.CheckCommand(cmdStr); err != nil {
+		return nil, err
+	}
+	if err := pol.Confirm(ctx, cmdStr); err != nil {
+		return nil, err
+	}
+
+	workDir, err := policy.ResolveDirectory(t.ProjectRoot, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx := ctx
+	var cancel context.CancelFunc
+	if pol.MaxWallClock > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, pol.MaxWallClock)
+		defer cancel()
 	}
 
-	var stdoutBuf, stderrBuf bytes.Buffer
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
+	cmd := exec.CommandContext(runCtx, "bash", "-c", pol.WrapCommand(cmdStr))
+	cmd.Dir = workDir
+	cmd.Env = pol.Env(os.Environ())
+
+	stdout := &policy.TruncatingWriter{Limit: pol.MaxOutputBytes}
+	stderr := &policy.TruncatingWriter{Limit: pol.MaxOutputBytes}
+
+	stdoutLog, stderrLog, logErr := t.openRunLogs()
+	if logErr == nil {
+		defer stdoutLog.Close()
+		defer stderrLog.Close()
+		cmd.Stdout = io.MultiWriter(stdout, stdoutLog)
+		cmd.Stderr = io.MultiWriter(stderr, stderrLog)
+	} else {
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+	}
 
 	// Set process group ID for background processes
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 
-	err := cmd.Start()
+	err = cmd.Start()
 	if err != nil {
 		return nil, fmt.Errorf("failed to start command: %w", err)
 	}
 
+	// Capture the PGID right away: once Wait returns the process may
+	// already be gone, and Getpgid on a reaped PID fails.
+	pgid, pgidErr := syscall.Getpgid(cmd.Process.Pid)
+	if pgidErr != nil {
+		pgid = -1
+	}
+
 	// Wait for the command to finish in a goroutine to allow context cancellation
 	done := make(chan error, 1)
 	go func() {
@@ -90,16 +143,22 @@ func (t *RunShellCommandTool) Execute(ctx context.Context, args map[string]any)
 	var exitCode int = -1
 	var signal int = -1
 	var cmdErr string = "(none)"
+	var backgroundPIDs []any = []any{}
+
+	grace := pol.ShutdownGrace
+	if grace <= 0 {
+		grace = defaultShutdownGrace
+	}
 
 	select {
-	case <-ctx.Done():
-		// Context cancelled, try to kill the process group
-		pgid, err := syscall.Getpgid(cmd.Process.Pid)
-		if err == nil {
-			syscall.Kill(-pgid, syscall.SIGTERM) // Kill the process group
+	case <-runCtx.Done():
+		// Context cancelled (caller cancellation or MaxWallClock): SIGTERM
+		// the group, give it `grace` to exit, then SIGKILL it.
+		if pgid != -1 {
+			_ = background.GracefulStop(pgid, grace)
 		}
 		<-done // Wait for the process to actually exit
-		return nil, ctx.Err()
+		return nil, runCtx.Err()
 	case err := <-done:
 		if err != nil {
 			if exitError, ok := err.(*exec.ExitError); ok {
@@ -118,24 +177,60 @@ func (t *RunShellCommandTool) Execute(ctx context.Context, args map[string]any)
 		}
 	}
 
-	pgid := -1
-	if cmd.Process != nil {
-		if p, err := syscall.Getpgid(cmd.Process.Pid); err == nil {
-			pgid = p
+	// Wait returned but the process group may still have live members
+	// (background jobs started with `&`); snapshot them now rather than
+	// killing them, and hand them off so they can be managed later.
+	if pgid != -1 {
+		if pids, err := background.ChildPIDs(pgid); err == nil && len(pids) > 0 {
+			backgroundPIDs = make([]any, len(pids))
+			for i, p := range pids {
+				backgroundPIDs[i] = p
+			}
+			if t.Background != nil && logErr == nil {
+				_ = t.Background.Track(&background.Group{
+					PGID:       pgid,
+					Command:    cmdStr,
+					Directory:  dir,
+					StartedAt:  time.Now(),
+					StdoutPath: stdoutLog.Name(),
+					StderrPath: stderrLog.Name(),
+				})
+			}
 		}
 	}
 
 	result := map[string]any{
 		"Command":            cmdStr,
 		"Directory":          dir,
-		"Stdout":             stdoutBuf.String(),
-		"Stderr":             stderrBuf.String(),
+		"Stdout":             stdout.String(),
+		"Stderr":             stderr.String(),
 		"Error":              cmdErr,
 		"Exit Code":          exitCode,
 		"Signal":             signal,
-		"Background PIDs":    []any{}, // Placeholder, difficult to get reliably
+		"Background PIDs":    backgroundPIDs,
 		"Process Group PGID": pgid,
 	}
 
 	return result, nil
 }
+
+// openRunLogs creates a fresh pair of stdout/stderr log files under
+// <ProjectRoot>/.thoth/logs, so output is still readable via
+// manage_background_process after this call returns and its in-memory
+// buffers are gone.
+func (t *RunShellCommandTool) openRunLogs() (stdout, stderr *os.File, err error) {
+	dir := filepath.Join(t.ProjectRoot, ".thoth", "logs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, err
+	}
+	stdout, err = os.CreateTemp(dir, "run-*.stdout.log")
+	if err != nil {
+		return nil, nil, err
+	}
+	stderr, err = os.CreateTemp(dir, "run-*.stderr.log")
+	if err != nil {
+		stdout.Close()
+		return nil, nil, err
+	}
+	return stdout, stderr, nil
+}
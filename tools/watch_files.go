@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"thoth/watch"
+
+	"google.golang.org/genai"
+)
+
+const (
+	defaultDebounceMs = 200
+	defaultTimeoutMs  = 30000
+)
+
+// WatchFilesTool blocks until a matching filesystem event fires under
+// ProjectRoot, so an agent loop can do "run tests, wait for a source
+// change, re-run" without polling ReadManyFilesTool in a loop.
+type WatchFilesTool struct {
+	ProjectRoot string
+
+	registry *watch.Registry
+}
+
+func (t *WatchFilesTool) Declaration() *genai.FunctionDeclaration {
+	return &genai.FunctionDeclaration{
+		Name:        "watch_files",
+		Description: "Blocks until a file matching the given paths/exclude/include globs changes under the project root, or until timeout_ms elapses. Returns the files that changed since the last call for this same pattern set (or since this call started, if it's the first). Use this instead of polling read_many_files in a loop.",
+		Parameters: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"paths": {
+					Type:        genai.TypeArray,
+					Description: "Required. Glob patterns or paths relative to the project root to observe. Same semantics as read_many_files' `paths`.",
+					Items:       &genai.Schema{Type: genai.TypeString},
+				},
+				"exclude": {
+					Type:        genai.TypeArray,
+					Description: "Optional. Glob patterns to exclude from observation.",
+					Items:       &genai.Schema{Type: genai.TypeString},
+				},
+				"include": {
+					Type:        genai.TypeArray,
+					Description: "Optional. Additional glob patterns to merge with `paths`.",
+					Items:       &genai.Schema{Type: genai.TypeString},
+				},
+				"events": {
+					Type:        genai.TypeArray,
+					Description: "Optional. Which event kinds to wait for: create, write, remove, rename. Defaults to all four.",
+					Items:       &genai.Schema{Type: genai.TypeString},
+				},
+				"debounce_ms": {
+					Type:        genai.TypeNumber,
+					Description: "Optional. Milliseconds to coalesce bursts of events before returning. Defaults to 200.",
+				},
+				"timeout_ms": {
+					Type:        genai.TypeNumber,
+					Description: "Optional. Milliseconds to wait for a matching event before returning an empty changeset. Defaults to 30000.",
+				},
+			},
+			Required: []string{"paths"},
+		},
+		Response: &genai.Schema{
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"changed":     {Type: genai.TypeArray},
+				"snapshot_id": {Type: genai.TypeString},
+			},
+		},
+	}
+}
+
+func (t *WatchFilesTool) Execute(ctx context.Context, args map[string]any) (map[string]any, error) {
+	paths, ok := stringSlice(args["paths"])
+	if !ok || len(paths) == 0 {
+		return nil, fmt.Errorf("missing or invalid 'paths' argument")
+	}
+	exclude, _ := stringSlice(args["exclude"])
+	include, _ := stringSlice(args["include"])
+
+	events := []watch.Event{watch.EventCreate, watch.EventWrite, watch.EventRemove, watch.EventRename}
+	if raw, ok := stringSlice(args["events"]); ok && len(raw) > 0 {
+		events = events[:0]
+		for _, e := range raw {
+			events = append(events, watch.Event(e))
+		}
+	}
+
+	debounce := time.Duration(floatArg(args["debounce_ms"], defaultDebounceMs)) * time.Millisecond
+	timeout := time.Duration(floatArg(args["timeout_ms"], defaultTimeoutMs)) * time.Millisecond
+
+	if t.registry == nil {
+		t.registry = watch.NewRegistry()
+	}
+
+	matcher := &globMatcher{paths: append(append([]string(nil), paths...), include...), exclude: exclude}
+	key := watch.Key(t.ProjectRoot, paths, exclude, include, events)
+
+	snap, err := t.registry.Watch(ctx, key, t.ProjectRoot, matcher, events, debounce, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("watch_files: %w", err)
+	}
+
+	changed := make([]map[string]any, len(snap.Changes))
+	for i, c := range snap.Changes {
+		changed[i] = map[string]any{
+			"path":  c.Path,
+			"event": string(c.Event),
+			"size":  c.Size,
+			"mtime": c.Mtime.Format(time.RFC3339Nano),
+		}
+	}
+
+	return map[string]any{
+		"changed":     changed,
+		"snapshot_id": snap.SnapshotID,
+	}, nil
+}
+
+// globMatcher matches a relative path against the same paths/exclude glob
+// semantics ReadManyFilesTool accepts.
+type globMatcher struct {
+	paths   []string
+	exclude []string
+}
+
+func (m *globMatcher) Matches(relPath string) bool {
+	included := false
+	for _, pattern := range m.paths {
+		if ok, _ := filepath.Match(pattern, relPath); ok {
+			included = true
+			break
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(relPath)); ok {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for _, pattern := range m.exclude {
+		if ok, _ := filepath.Match(pattern, relPath); ok {
+			return false
+		}
+	}
+	return true
+}
+
+func stringSlice(v any) ([]string, bool) {
+	raw, ok := v.([]any)
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, 0, len(raw))
+	for _, e := range raw {
+		s, ok := e.(string)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, s)
+	}
+	return out, true
+}
+
+func floatArg(v any, def float64) float64 {
+	if f, ok := v.(float64); ok {
+		return f
+	}
+	return def
+}
@@ -0,0 +1,239 @@
+// Package watch wraps fsnotify to observe files and directories under a
+// project root and report only what changed since a caller's last look, so
+// an agent loop can wait for a source change instead of polling for one.
+package watch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Event is the kind of filesystem change reported to a watcher.
+type Event string
+
+const (
+	EventCreate Event = "create"
+	EventWrite  Event = "write"
+	EventRemove Event = "remove"
+	EventRename Event = "rename"
+)
+
+// Change describes a single observed filesystem event.
+type Change struct {
+	Path  string    `json:"path"`
+	Event Event     `json:"event"`
+	Size  int64     `json:"size"`
+	Mtime time.Time `json:"mtime"`
+}
+
+// Snapshot is the delta returned by a call to Registry.Watch: the changes
+// seen since the previous snapshot for this pattern set, plus an opaque id
+// to pass on the next call.
+type Snapshot struct {
+	Changes    []Change
+	SnapshotID string
+}
+
+// Matcher decides whether a relative path should be observed. Callers
+// typically pass the same include/exclude logic used by ReadManyFilesTool.
+type Matcher interface {
+	Matches(relPath string) bool
+}
+
+// watcherState is the live fsnotify watch plus the changes accumulated since
+// the last snapshot was handed out for its pattern set.
+type watcherState struct {
+	mu      sync.Mutex
+	fsw     *fsnotify.Watcher
+	pending []Change
+	seq     int
+	done    chan struct{}
+}
+
+// Registry is a per-session set of watchers keyed by pattern set, so
+// successive WatchFiles calls for the same paths/exclude/include/events only
+// report deltas since the caller's last snapshot.
+type Registry struct {
+	mu       sync.Mutex
+	watchers map[string]*watcherState
+}
+
+// NewRegistry returns an empty watcher registry.
+func NewRegistry() *Registry {
+	return &Registry{watchers: make(map[string]*watcherState)}
+}
+
+// Key builds the pattern-set key a Registry uses to reuse watchers across
+// calls. Order-independent so callers don't need to normalize slices first.
+func Key(root string, paths, exclude, include []string, events []Event) string {
+	norm := func(ss []string) string {
+		cp := append([]string(nil), ss...)
+		sort.Strings(cp)
+		return strings.Join(cp, "\x1f")
+	}
+	evs := make([]string, len(events))
+	for i, e := range events {
+		evs[i] = string(e)
+	}
+	sort.Strings(evs)
+	h := sha256.Sum256([]byte(root + "\x00" + norm(paths) + "\x00" + norm(exclude) + "\x00" + norm(include) + "\x00" + strings.Join(evs, ",")))
+	return hex.EncodeToString(h[:])[:16]
+}
+
+// minInterval is the floor applied to debounce/timeout before they reach
+// time.NewTicker/time.NewTimer, both of which panic on a non-positive
+// duration; a caller-supplied 0 (or negative) value is clamped up to this
+// instead of crashing the process.
+const minInterval = time.Millisecond
+
+// Watch blocks until a matching event fires, the timeout elapses, or ctx is
+// done, then returns the changes accumulated since the last call for this
+// pattern set. The first call for a new pattern set establishes the watcher
+// and waits for the first event (there is nothing to diff against yet).
+func (r *Registry) Watch(ctx context.Context, key, root string, matcher Matcher, events []Event, debounce, timeout time.Duration) (*Snapshot, error) {
+	if debounce < minInterval {
+		debounce = minInterval
+	}
+	if timeout < minInterval {
+		timeout = minInterval
+	}
+
+	r.mu.Lock()
+	ws, ok := r.watchers[key]
+	if !ok {
+		fsw, err := fsnotify.NewWatcher()
+		if err != nil {
+			r.mu.Unlock()
+			return nil, fmt.Errorf("create watcher: %w", err)
+		}
+		if err := addRecursive(fsw, root); err != nil {
+			fsw.Close()
+			r.mu.Unlock()
+			return nil, fmt.Errorf("watch %s: %w", root, err)
+		}
+		ws = &watcherState{fsw: fsw, done: make(chan struct{})}
+		r.watchers[key] = ws
+		wanted := make(map[Event]bool, len(events))
+		for _, e := range events {
+			wanted[e] = true
+		}
+		go ws.collect(root, matcher, wanted)
+	}
+	r.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(debounce)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return ws.snapshot(), nil
+		case <-ticker.C:
+			if snap := ws.snapshot(); len(snap.Changes) > 0 {
+				return snap, nil
+			}
+		}
+	}
+}
+
+// Close tears down every watcher owned by the registry.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var firstErr error
+	for key, ws := range r.watchers {
+		close(ws.done)
+		if err := ws.fsw.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(r.watchers, key)
+	}
+	return firstErr
+}
+
+func (ws *watcherState) collect(root string, matcher Matcher, wanted map[Event]bool) {
+	for {
+		select {
+		case <-ws.done:
+			return
+		case ev, ok := <-ws.fsw.Events:
+			if !ok {
+				return
+			}
+			kind, ok := translate(ev.Op)
+			if !ok || !wanted[kind] {
+				continue
+			}
+			rel, err := filepath.Rel(root, ev.Name)
+			if err != nil {
+				rel = ev.Name
+			}
+			if matcher != nil && !matcher.Matches(rel) {
+				continue
+			}
+			var size int64
+			var mtime time.Time
+			if fi, err := os.Stat(ev.Name); err == nil {
+				size = fi.Size()
+				mtime = fi.ModTime()
+			}
+			ws.mu.Lock()
+			ws.pending = append(ws.pending, Change{Path: rel, Event: kind, Size: size, Mtime: mtime})
+			ws.mu.Unlock()
+		case <-ws.fsw.Errors:
+			// Best-effort: dropped errors don't stop the watch.
+		}
+	}
+}
+
+func (ws *watcherState) snapshot() *Snapshot {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	changes := ws.pending
+	ws.pending = nil
+	ws.seq++
+	return &Snapshot{Changes: changes, SnapshotID: fmt.Sprintf("%p-%d", ws, ws.seq)}
+}
+
+func translate(op fsnotify.Op) (Event, bool) {
+	switch {
+	case op&fsnotify.Create != 0:
+		return EventCreate, true
+	case op&fsnotify.Write != 0:
+		return EventWrite, true
+	case op&fsnotify.Remove != 0:
+		return EventRemove, true
+	case op&fsnotify.Rename != 0:
+		return EventRename, true
+	default:
+		return "", false
+	}
+}
+
+// addRecursive adds root and every subdirectory beneath it to fsw, since
+// fsnotify only watches the directories it's explicitly told about.
+func addRecursive(fsw *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return fsw.Add(path)
+		}
+		return nil
+	})
+}